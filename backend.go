@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Backend abstracts the handful of S3 operations the toolkit needs, so that
+// besides real AWS S3 it can also target any S3-compatible service (MinIO,
+// Ceph RGW, Wasabi, GCS-XML, ...) reachable via a custom endpoint.
+type Backend interface {
+	HeadObject(ctx context.Context, bucket, key string) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error)
+	ListObjectsV2Pages(ctx context.Context, input *s3.ListObjectsV2Input, fn func(page *s3.ListObjectsV2Output) bool) error
+
+	// The multipart copy operations used for objects too large for a single
+	// server-side CopyObject call (>5GiB), see multipart.go.
+	CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartCopy(ctx context.Context, input *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error)
+	CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error)
+
+	// HasCloudWatch reports whether this backend's bucket metrics can be read
+	// from AWS CloudWatch. Non-AWS backends never have that, and getExpectedSize
+	// falls back to an incremental ListObjectsV2 count in that case.
+	HasCloudWatch() bool
+}
+
+// BackendOptions configures how a Backend talks to its S3-compatible service.
+type BackendOptions struct {
+	Endpoint   string
+	PathStyle  bool
+	DisableSSL bool
+}
+
+type s3Backend struct {
+	client        *s3.Client
+	hasCloudWatch bool
+}
+
+// newBackend builds a Backend from an aws.Config and endpoint options. A
+// non-empty Endpoint always means a non-AWS (or AWS-compatible, non-default)
+// service, so CloudWatch-based sizing is disabled for it.
+func newBackend(cfg aws.Config, opts BackendOptions) Backend {
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = opts.PathStyle
+		if opts.Endpoint != "" {
+			endpoint := opts.Endpoint
+			if opts.DisableSSL {
+				endpoint = strings.Replace(endpoint, "https://", "http://", 1)
+			}
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+	return &s3Backend{client: client, hasCloudWatch: opts.Endpoint == ""}
+}
+
+func (b *s3Backend) HeadObject(ctx context.Context, bucket, key string) (*s3.HeadObjectOutput, error) {
+	return b.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+}
+
+func (b *s3Backend) CopyObject(ctx context.Context, input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	return b.client.CopyObject(ctx, input)
+}
+
+func (b *s3Backend) ListObjectsV2Pages(ctx context.Context, input *s3.ListObjectsV2Input, fn func(page *s3.ListObjectsV2Output) bool) error {
+	paginator := s3.NewListObjectsV2Paginator(b.client, input)
+	for paginator.HasMorePages() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if !fn(page) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (b *s3Backend) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	return b.client.CreateMultipartUpload(ctx, input)
+}
+
+func (b *s3Backend) UploadPartCopy(ctx context.Context, input *s3.UploadPartCopyInput) (*s3.UploadPartCopyOutput, error) {
+	return b.client.UploadPartCopy(ctx, input)
+}
+
+func (b *s3Backend) CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	return b.client.CompleteMultipartUpload(ctx, input)
+}
+
+func (b *s3Backend) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	return b.client.AbortMultipartUpload(ctx, input)
+}
+
+func (b *s3Backend) HasCloudWatch() bool {
+	return b.hasCloudWatch
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "s3-assets-toolkit"
+	app.Usage = "Copy objects between S3 buckets while setting Cache-Control and Content-Type"
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "target-bucket", Usage: "Bucket to copy objects to (required)"},
+		cli.StringFlag{Name: "from-bucket", Usage: "Bucket to copy objects from (defaults to --target-bucket)"},
+		cli.StringFlag{Name: "cache-control", Usage: "New Cache-Control header value to set on copied objects"},
+		cli.IntFlag{Name: "parallelity", Value: 200, Usage: "Number of concurrent copy workers"},
+		cli.BoolFlag{Name: "noop", Usage: "List and report what would be copied, without actually copying"},
+		cli.StringFlag{Name: "exclude-pictures", Usage: "Regexp of keys to exclude from the Cache-Control change when they are pictures"},
+		cli.Int64Flag{Name: "first-n", Usage: "Stop after copying this many objects (0 = no limit)"},
+		cli.StringFlag{Name: "continue", Usage: "Key (or prefix=key,prefix=key,... when sharded) to continue listing after"},
+		cli.BoolFlag{Name: "stdin", Usage: "Read object keys to copy from stdin instead of listing the bucket"},
+
+		cli.StringFlag{Name: "cross-account-cloudwatch-role", Usage: "Role ARN to assume for reading the source bucket's CloudWatch metrics"},
+		cli.StringFlag{Name: "source-role", Usage: "Role ARN to assume for the source bucket"},
+		cli.StringFlag{Name: "target-role", Usage: "Role ARN to assume for the target bucket"},
+		cli.StringFlag{Name: "external-id", Usage: "ExternalId for the assumed roles, if required"},
+		cli.StringFlag{Name: "role-session-name", Value: "s3-assets-toolkit", Usage: "RoleSessionName for the assumed roles"},
+		cli.DurationFlag{Name: "duration", Usage: "Duration of the assumed role sessions"},
+		cli.StringFlag{Name: "mfa-serial", Usage: "MFA device serial number, if the assumed roles require MFA"},
+		cli.StringFlag{Name: "region", Usage: "AWS region (defaults to the usual SDK resolution)"},
+
+		cli.StringFlag{Name: "endpoint", Usage: "Custom S3-compatible endpoint for both source and target (MinIO, Ceph RGW, Wasabi, ...)"},
+		cli.StringFlag{Name: "source-endpoint", Usage: "Custom S3-compatible endpoint for the source bucket only, overrides --endpoint (must match --target-endpoint: copies are server-side within one service)"},
+		cli.StringFlag{Name: "target-endpoint", Usage: "Custom S3-compatible endpoint for the target bucket only, overrides --endpoint (must match --source-endpoint: copies are server-side within one service)"},
+		cli.BoolFlag{Name: "path-style", Usage: "Use path-style addressing, required by most non-AWS S3-compatible backends"},
+		cli.BoolFlag{Name: "disable-ssl", Usage: "Use http:// instead of https:// for --endpoint/--source-endpoint/--target-endpoint"},
+
+		cli.Int64Flag{Name: "multipart-threshold", Value: defaultMultipartThreshold, Usage: "Objects larger than this use multipart UploadPartCopy instead of a single CopyObject"},
+		cli.Int64Flag{Name: "part-size", Value: defaultPartSize, Usage: "Part size for multipart copies"},
+		cli.IntFlag{Name: "part-concurrency", Value: defaultPartConcurrency, Usage: "Concurrent UploadPartCopy calls per multipart object"},
+
+		cli.IntFlag{Name: "list-shards", Usage: "Split listing into this many concurrent, key-prefix-sharded listings"},
+		cli.StringFlag{Name: "list-shard-alphabet", Value: defaultShardAlphabet, Usage: "Alphabet to derive --list-shards prefixes from"},
+		cli.StringFlag{Name: "prefix-file", Usage: "File with one explicit listing prefix per line, instead of --list-shards"},
+		cli.Int64Flag{Name: "list-page-size", Usage: "ListObjectsV2 page size (defaults to a size derived from --first-n)"},
+
+		cli.StringFlag{Name: "metrics-listen", Usage: "Address to serve Prometheus /metrics on, e.g. :9090 (disabled if empty)"},
+		cli.StringFlag{Name: "progress", Value: "tty", Usage: "Terminal progress output: tty, json or none"},
+
+		cli.StringFlag{Name: "job-id", Usage: "Track progress under this id so an interrupted run can be resumed, see the resume/verify commands"},
+		cli.StringFlag{Name: "job-dir", Value: "./jobs", Usage: "Directory holding --job-id state"},
+
+		cli.Float64Flag{Name: "rps", Usage: "Cap requests per second shared across all workers (0 = unlimited)"},
+		cli.IntFlag{Name: "burst", Value: 50, Usage: "Token bucket burst size for --rps"},
+		cli.IntFlag{Name: "max-retries", Value: defaultMaxRetries, Usage: "Max attempts per request before giving up on SlowDown/throttling errors"},
+	}
+
+	app.Action = func(c *cli.Context) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		return executeCopy(ctx, optionsFromCli(c))
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:      "resume",
+			Usage:     "Resume a previously interrupted --job-id run from its journal",
+			ArgsUsage: "JOB_ID",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "job-dir", Value: "./jobs", Usage: "Directory holding --job-id state"},
+			},
+			Action: func(c *cli.Context) error {
+				jobID := c.Args().First()
+				if jobID == "" {
+					return cli.NewExitError("\n\nError: resume requires a JOB_ID argument\n", 1)
+				}
+				opts, err := LoadJobConfig(c.String("job-dir"), jobID)
+				if err != nil {
+					return err
+				}
+				opts.JobID = jobID
+				opts.JobDir = c.String("job-dir")
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+				defer cancel()
+				return executeCopy(ctx, opts)
+			},
+		},
+		{
+			Name:      "verify",
+			Usage:     "Re-check every key a --job-id run recorded as done against the target bucket",
+			ArgsUsage: "JOB_ID",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "job-dir", Value: "./jobs", Usage: "Directory holding --job-id state"},
+			},
+			Action: func(c *cli.Context) error {
+				jobID := c.Args().First()
+				if jobID == "" {
+					return cli.NewExitError("\n\nError: verify requires a JOB_ID argument\n", 1)
+				}
+				opts, err := LoadJobConfig(c.String("job-dir"), jobID)
+				if err != nil {
+					return err
+				}
+				opts.JobID = jobID
+				opts.JobDir = c.String("job-dir")
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+				defer cancel()
+				return verifyJob(ctx, opts)
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	journalLogName    = "journal.log"
+	jobConfigName     = "config.json"
+	statusRecoverable = "R" // retried on the next --job-id resume, unlike a terminal status
+)
+
+// Journal is a crash-safe, append-only record of every key attempted under a
+// --job-id. It backs `resume` (skip keys already recorded with a terminal
+// status while relisting from the start, retry ones recorded as recoverable)
+// and `verify` (re-check everything recorded as done).
+//
+// Resume relists the bucket from the start rather than fast-forwarding to a
+// checkpoint: the listing order and the order in which concurrent workers
+// finish keys don't match, so there is no single "last acknowledged key" a
+// watermark could safely skip past without risking a still-in-flight or
+// not-yet-dequeued key never being listed again. IsDone is the only
+// skip mechanism, and it is exact because it is keyed by the key itself, not
+// by listing position.
+//
+// The log is append-only and replayed in full on open; for the run lengths
+// this tool targets (millions of keys, one short line each) that is cheap
+// compared to the S3 calls it gates, so no separate compaction step exists -
+// the last line for a key simply wins during replay.
+type Journal struct {
+	dir string
+
+	mu      sync.Mutex
+	logFile *os.File
+	done    map[string]string // key -> last recorded status
+}
+
+// openJournal creates (or reopens) jobDir/jobID and replays its journal.log.
+func openJournal(jobDir, jobID string) (*Journal, error) {
+	dir := filepath.Join(jobDir, jobID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create job directory '%s': %v", dir, err)
+	}
+
+	j := &Journal{dir: dir, done: map[string]string{}}
+	if err := j.replayLog(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, journalLogName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal log: %v", err)
+	}
+	j.logFile = f
+	return j, nil
+}
+
+func (j *Journal) replayLog() error {
+	f, err := os.Open(filepath.Join(j.dir, journalLogName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		j.done[parts[1]] = parts[0]
+	}
+	return scanner.Err()
+}
+
+// IsDone reports whether key already has a terminal (non-retryable) status
+// recorded from a previous, interrupted run of this --job-id.
+func (j *Journal) IsDone(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status, ok := j.done[key]
+	return ok && status != statusRecoverable
+}
+
+// Record appends key's outcome to the journal log. Pass statusRecoverable
+// for keys that failed with a transient error, so a later resume retries
+// them instead of skipping them as done.
+func (j *Journal) Record(key, status string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done[key] = status
+	fmt.Fprintf(j.logFile, "%s\t%s\t%s\n", status, key, time.Now().Format(time.RFC3339))
+}
+
+// DoneKeys returns every key recorded with a terminal status, for `verify`.
+func (j *Journal) DoneKeys() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	keys := make([]string, 0, len(j.done))
+	for key, status := range j.done {
+		if status != statusRecoverable {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (j *Journal) Close() error {
+	return j.logFile.Close()
+}
+
+// SaveConfig persists the RunOptions a job was started with to config.json,
+// so `resume` and `verify` don't need the original flags repeated.
+func (j *Journal) SaveConfig(opts RunOptions) error {
+	data, err := json.MarshalIndent(opts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(j.dir, jobConfigName), data, 0644)
+}
+
+// LoadJobConfig reads back the RunOptions saved by SaveConfig for jobID.
+func LoadJobConfig(jobDir, jobID string) (RunOptions, error) {
+	var opts RunOptions
+	data, err := os.ReadFile(filepath.Join(jobDir, jobID, jobConfigName))
+	if err != nil {
+		return opts, fmt.Errorf("reading job config for '%s': %v", jobID, err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("parsing job config for '%s': %v", jobID, err)
+	}
+	return opts, nil
+}
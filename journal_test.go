@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestJournalRecordAndIsDone(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir, "job1")
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	defer j.Close()
+
+	if j.IsDone("a") {
+		t.Fatalf("IsDone(%q) = true before any record", "a")
+	}
+
+	j.Record("a", ".")
+	j.Record("b", statusRecoverable)
+
+	if !j.IsDone("a") {
+		t.Errorf("IsDone(%q) = false, want true for terminal status", "a")
+	}
+	if j.IsDone("b") {
+		t.Errorf("IsDone(%q) = true, want false for recoverable status", "b")
+	}
+}
+
+func TestJournalReplayLog(t *testing.T) {
+	dir := t.TempDir()
+	j1, err := openJournal(dir, "job1")
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	j1.Record("a", ".")
+	j1.Record("b", statusRecoverable)
+	j1.Close()
+
+	j2, err := openJournal(dir, "job1")
+	if err != nil {
+		t.Fatalf("reopen openJournal: %v", err)
+	}
+	defer j2.Close()
+
+	if !j2.IsDone("a") {
+		t.Errorf("IsDone(%q) = false after replay, want true", "a")
+	}
+	if j2.IsDone("b") {
+		t.Errorf("IsDone(%q) = true after replay, want false (recoverable)", "b")
+	}
+}
+
+func TestJournalDoneKeys(t *testing.T) {
+	dir := t.TempDir()
+	j, err := openJournal(dir, "job1")
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	defer j.Close()
+
+	j.Record("a", ".")
+	j.Record("b", statusRecoverable)
+	j.Record("c", "X")
+
+	keys := j.DoneKeys()
+	if len(keys) != 2 {
+		t.Fatalf("DoneKeys() = %v, want 2 terminal keys", keys)
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		seen[k] = true
+	}
+	if !seen["a"] || !seen["c"] {
+		t.Errorf("DoneKeys() = %v, want a and c, not b", keys)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultShardAlphabet matches the hex-prefix key layout recommended for
+// high-throughput S3 buckets, so that --list-shards without --prefix-file
+// gives one shard per hex digit.
+const defaultShardAlphabet = "0123456789abcdef"
+
+// shardPrefixes turns an alphabet string into one single-character prefix per
+// rune, e.g. "0123456789abcdef" -> ["0", "1", ..., "f"], capped to the first n
+// characters (n<=0 or n>=len(alphabet) uses the whole alphabet).
+func shardPrefixes(alphabet string, n int) []string {
+	runes := []rune(alphabet)
+	if n > 0 && n < len(runes) {
+		runes = runes[:n]
+	}
+	prefixes := make([]string, 0, len(runes))
+	for _, r := range runes {
+		prefixes = append(prefixes, string(r))
+	}
+	return prefixes
+}
+
+// readPrefixFile reads one prefix per line from path, skipping blank lines
+// and '#' comments, for --prefix-file.
+func readPrefixFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prefixes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefixes = append(prefixes, line)
+	}
+	return prefixes, scanner.Err()
+}
+
+// parseContinuePoints parses the --continue flag. A bare key (no '=')
+// continues the single, unsharded listing. A comma-separated list of
+// "prefix=key" pairs gives each shard its own StartAfter key, so --continue
+// keeps working once listing is split across --list-shards.
+func parseContinuePoints(raw string) map[string]string {
+	points := map[string]string{}
+	if raw == "" {
+		return points
+	}
+	if !strings.Contains(raw, "=") {
+		points[""] = raw
+		return points
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			points[kv[0]] = kv[1]
+		}
+	}
+	return points
+}
+
+// listObjectsSharded spawns one concurrent listObjectsToCopy per prefix,
+// each feeding the shared names channel, and waits for all of them to drain.
+func listObjectsSharded(ctx context.Context, names chan<- string, context *CopyContext, prefixes []string, continuePoints map[string]string) {
+	var wg sync.WaitGroup
+	for _, prefix := range prefixes {
+		prefix := prefix
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listObjectsToCopy(ctx, names, context.from, continuePoints[prefix], prefix, context)
+		}()
+	}
+	wg.Wait()
+}
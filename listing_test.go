@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShardPrefixes(t *testing.T) {
+	cases := []struct {
+		alphabet string
+		n        int
+		want     []string
+	}{
+		{"0123456789abcdef", 0, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "a", "b", "c", "d", "e", "f"}},
+		{"0123456789abcdef", 4, []string{"0", "1", "2", "3"}},
+		{"0123456789abcdef", 100, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "a", "b", "c", "d", "e", "f"}},
+		{"ab", -1, []string{"a", "b"}},
+	}
+	for _, c := range cases {
+		got := shardPrefixes(c.alphabet, c.n)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("shardPrefixes(%q, %d) = %v, want %v", c.alphabet, c.n, got, c.want)
+		}
+	}
+}
+
+func TestParseContinuePointsUnsharded(t *testing.T) {
+	got := parseContinuePoints("last-key")
+	want := map[string]string{"": "last-key"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseContinuePoints(%q) = %v, want %v", "last-key", got, want)
+	}
+}
+
+func TestParseContinuePointsSharded(t *testing.T) {
+	got := parseContinuePoints("a=a/5,b=b/9")
+	want := map[string]string{"a": "a/5", "b": "b/9"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseContinuePoints(...) = %v, want %v", got, want)
+	}
+}
+
+func TestParseContinuePointsEmpty(t *testing.T) {
+	got := parseContinuePoints("")
+	if len(got) != 0 {
+		t.Errorf("parseContinuePoints(\"\") = %v, want empty map", got)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	objectsCopiedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "objects_copied_total",
+		Help: "Number of objects processed, by final status code",
+	}, []string{"status"})
+
+	objectsFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "objects_failed_total",
+		Help: "Number of objects that failed to copy",
+	})
+
+	bytesCopiedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bytes_copied_total",
+		Help: "Total bytes server-side copied",
+	})
+
+	headRequestsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "head_requests_total",
+		Help: "Total HeadObject requests issued against source and target",
+	})
+
+	copyDurationSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "copy_duration_seconds",
+		Help:    "Duration of a single object's copy, from the first HeadObject to completion",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	expectedObjectsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "expected_objects",
+		Help: "Estimated total number of objects to process this run",
+	})
+)
+
+// serveMetrics starts the /metrics endpoint in the background if listen is
+// non-empty (--metrics-listen). It does not block the caller.
+func serveMetrics(listen string) {
+	if listen == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(listen, mux); err != nil {
+			log.Printf("metrics server on %s stopped: %v\n", listen, err)
+		}
+	}()
+}
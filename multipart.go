@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// defaultMultipartThreshold, defaultPartSize and defaultPartConcurrency are
+// the defaults behind --multipart-threshold, --part-size and --part-concurrency.
+const (
+	defaultMultipartThreshold = int64(4.5 * 1024 * 1024 * 1024) // 4.5 GiB, below the 5 GiB CopyObject limit
+	defaultPartSize           = int64(100 * 1024 * 1024)        // 100 MiB
+	defaultPartConcurrency    = 4
+)
+
+type partResult struct {
+	partNumber int32
+	etag       *string
+	err        error
+}
+
+// multipartCopy server-side copies a single object too large for CopyObject
+// (>5 GiB) by splitting it into UploadPartCopy ranges dispatched to a small
+// worker pool, then completing (or aborting, on error) the multipart upload.
+// A fresh UploadId is created on every call, so retrying a failed copy never
+// reuses a stale, partially-completed upload; a failed part copy or a failed
+// completion aborts the upload, and a failed abort is reported in the error.
+func multipartCopy(ctx context.Context, context *CopyContext, key string, size int64, contenttype *string) error {
+	create, err := context.targetsvc.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(context.target),
+		Key:          aws.String(key),
+		CacheControl: aws.String(context.newvalue),
+		ContentType:  contenttype,
+	})
+	if err != nil {
+		return fmt.Errorf("create multipart upload for '%s': %v", key, err)
+	}
+	uploadID := create.UploadId
+
+	partSize := context.partSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	numParts := int(size / partSize)
+	if size%partSize != 0 {
+		numParts++
+	}
+
+	src := fmt.Sprintf("%s/%s", context.from, url.PathEscape(key))
+	partNumbers := make(chan int32, numParts)
+	for i := int32(1); i <= int32(numParts); i++ {
+		partNumbers <- i
+	}
+	close(partNumbers)
+
+	concurrency := context.partConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make(chan partResult, numParts)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNumber := range partNumbers {
+				start := int64(partNumber-1) * partSize
+				end := start + partSize - 1
+				if end >= size {
+					end = size - 1
+				}
+				var out *s3.UploadPartCopyOutput
+				err := withRetry(ctx, context.limiter, context.maxAttempts, func() error {
+					var err error
+					out, err = context.targetsvc.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+						Bucket:          aws.String(context.target),
+						Key:             aws.String(key),
+						UploadId:        uploadID,
+						PartNumber:      aws.Int32(partNumber),
+						CopySource:      aws.String(src),
+						CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+					})
+					return err
+				})
+				if err != nil {
+					results <- partResult{partNumber: partNumber, err: err}
+					continue
+				}
+				results <- partResult{partNumber: partNumber, etag: out.CopyPartResult.ETag}
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	completed := make([]types.CompletedPart, 0, numParts)
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		completed = append(completed, types.CompletedPart{ETag: r.etag, PartNumber: aws.Int32(r.partNumber)})
+	}
+
+	if firstErr != nil {
+		_, abortErr := context.targetsvc.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket: aws.String(context.target), Key: aws.String(key), UploadId: uploadID,
+		})
+		if abortErr != nil {
+			return fmt.Errorf("UploadPartCopy failed for '%s': %v (and AbortMultipartUpload also failed: %v)", key, firstErr, abortErr)
+		}
+		return fmt.Errorf("UploadPartCopy failed for '%s', multipart upload aborted: %v", key, firstErr)
+	}
+
+	sort.Slice(completed, func(i, j int) bool { return *completed[i].PartNumber < *completed[j].PartNumber })
+
+	_, err = context.targetsvc.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(context.target),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		_, abortErr := context.targetsvc.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket: aws.String(context.target), Key: aws.String(key), UploadId: uploadID,
+		})
+		if abortErr != nil {
+			return fmt.Errorf("complete multipart upload for '%s': %v (and AbortMultipartUpload also failed: %v)", key, err, abortErr)
+		}
+		return fmt.Errorf("complete multipart upload for '%s', multipart upload aborted: %v", key, err)
+	}
+	return nil
+}
@@ -2,16 +2,10 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/cloudwatch"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/sts"
-	"gopkg.in/urfave/cli.v1"
-	"math"
+	"log/slog"
 	"net/url"
 	"os"
 	"regexp"
@@ -19,44 +13,82 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 )
 
-func assumeRoleCrossAccount(role string) (*aws.Config, error) {
-	security := sts.New(session.New())
-	input := &sts.AssumeRoleInput{
-		DurationSeconds: aws.Int64(3600),
-		ExternalId:      aws.String("123ABC"),
-		RoleArn:         &role,
-		RoleSessionName: aws.String("PutCacheControlImpersonification"),
+// RoleOptions describes an optional STS AssumeRole hop applied on top of the
+// default credentials chain (environment, shared config profile, EC2/ECS
+// instance metadata). Source bucket, target bucket and CloudWatch each get
+// their own RoleOptions so the three can live in different accounts.
+type RoleOptions struct {
+	RoleArn     string
+	ExternalID  string
+	SessionName string
+	Duration    time.Duration
+	MFASerial   string
+}
+
+func (r RoleOptions) empty() bool {
+	return r.RoleArn == ""
+}
+
+// loadAWSConfig resolves an aws.Config from the default credentials chain and,
+// if role.RoleArn is set, layers an STS AssumeRole on top of it.
+func loadAWSConfig(ctx context.Context, region string, role RoleOptions) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if region != "" {
+		optFns = append(optFns, config.WithRegion(region))
 	}
-	impersonated, err := security.AssumeRole(input)
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
 	if err != nil {
-		return nil, fmt.Errorf("assume role '%s' for cross-account access failed: %v", role, err)
+		return aws.Config{}, fmt.Errorf("can not create AWS SDK config: %v", err)
+	}
+	if role.empty() {
+		return cfg, nil
 	}
 
-	c := *impersonated.Credentials
-	tmpCreds := credentials.NewStaticCredentials(*c.AccessKeyId, *c.SecretAccessKey, *c.SessionToken)
-	return aws.NewConfig().WithCredentials(tmpCreds), nil
+	stsClient := sts.NewFromConfig(cfg)
+	provider := stscreds.NewAssumeRoleProvider(stsClient, role.RoleArn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = role.SessionName
+		if role.Duration > 0 {
+			o.Duration = role.Duration
+		}
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+		if role.MFASerial != "" {
+			o.SerialNumber = aws.String(role.MFASerial)
+			o.TokenProvider = stscreds.StdinTokenProvider
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+	return cfg, nil
 }
 
-// Find out the number of objects in the bucket
-// func getBucketSize(svc cloudwatch.CloudWatch) (int64, error) {
-func getBucketSize(bucketName string, conf *aws.Config) (int64, error) {
-	svcCrossAccount := cloudwatch.New(session.New(), conf)
-	dims := []*cloudwatch.Dimension{
-		&cloudwatch.Dimension{Name: aws.String("BucketName"), Value: aws.String(bucketName)},
-		&cloudwatch.Dimension{Name: aws.String("StorageType"), Value: aws.String("AllStorageTypes")},
+// Find out the number of objects in the bucket via CloudWatch.
+func getBucketSize(ctx context.Context, cwClient *cloudwatch.Client, bucketName string) (int64, error) {
+	dims := []cwtypes.Dimension{
+		{Name: aws.String("BucketName"), Value: aws.String(bucketName)},
+		{Name: aws.String("StorageType"), Value: aws.String("AllStorageTypes")},
 	}
 	req := cloudwatch.GetMetricStatisticsInput{
 		Namespace:  aws.String("AWS/S3"),
 		StartTime:  aws.Time(time.Now().Add(-time.Hour * 24 * 3)),
 		EndTime:    aws.Time(time.Now()),
-		Period:     aws.Int64(3600), // TODO try out Period: 86400 (one day)
-		Statistics: []*string{aws.String("Maximum")},
+		Period:     aws.Int32(3600), // TODO try out Period: 86400 (one day)
+		Statistics: []cwtypes.Statistic{cwtypes.StatisticMaximum},
 		MetricName: aws.String("NumberOfObjects"),
 		Dimensions: dims,
 	}
-	resp, err := svcCrossAccount.GetMetricStatistics(&req)
+	resp, err := cwClient.GetMetricStatistics(ctx, &req)
 	if err != nil {
 		return 0, fmt.Errorf("Failed to detect bucket '%s' size: %v", bucketName, err)
 	}
@@ -75,23 +107,23 @@ func getBucketSize(bucketName string, conf *aws.Config) (int64, error) {
 
 // Quickly find out the size of the bucket to copy for a nice progress indicator.
 // Side effect: modifies the context
-func getExpectedSize(context *CopyContext) {
-	var err error
-	context.expectedObjects, err = getBucketSize(context.from, &aws.Config{})
-	if err != nil && context.cloudwatchRole != "" {
-		// Retry getBucketSize using assume role (cross-account),
-		// first acquire temporary cross-account credentials (AWS STS)
-		confCrossAccount, errRole := assumeRoleCrossAccount(context.cloudwatchRole)
-		if errRole != nil {
-			os.Stderr.WriteString(fmt.Sprintf("Failed to detect 'from' bucket size: %v\n", errRole))
-			context.expectedObjects = 0 // unknown
-			return
-		}
-		context.expectedObjects, err = getBucketSize(context.from, confCrossAccount)
+//
+// When the source backend has no CloudWatch (a non-AWS backend, see
+// --source-endpoint), there is no upfront total: expectedObjects stays 0 and
+// listObjectsToCopy instead grows it incrementally as keys are discovered.
+func getExpectedSize(ctx context.Context, context *CopyContext) {
+	if !context.sourcesvc.HasCloudWatch() {
+		context.streamingSize = true
+		fmt.Printf("Source backend has no CloudWatch metrics; estimating size by streaming the listing instead.\n")
+		return
 	}
+
+	var err error
+	context.expectedObjects, err = getBucketSize(ctx, context.cwsvc, context.from)
 	if err != nil {
 		os.Stderr.WriteString(fmt.Sprintf("Failed to detect 'from' bucket size: %v\n", err))
-		context.expectedObjects = 0 // unknown
+		context.streamingSize = true
+		context.expectedObjects = 0 // unknown, fall back to streaming count
 		return
 	}
 	fmt.Printf("Objects in the 'from' bucket: %d\n", context.expectedObjects)
@@ -99,7 +131,7 @@ func getExpectedSize(context *CopyContext) {
 
 // listObjectsFromStdin reads from stdin one object name per line.
 // Also supports wildcard * at the end of the name.
-func listObjectsFromStdin(names chan<- string, context *CopyContext) {
+func listObjectsFromStdin(ctx context.Context, names chan<- string, context *CopyContext) {
 	input := bufio.NewScanner(os.Stdin)
 	for input.Scan() {
 		name := strings.TrimSpace(input.Text())
@@ -111,18 +143,18 @@ func listObjectsFromStdin(names chan<- string, context *CopyContext) {
 			if strings.HasPrefix(name, "/") {
 				prefix = prefix[1:] // remove leading slash if any
 			}
-			listObjectsToCopy(names, context.from, "", prefix, context)
+			listObjectsToCopy(ctx, names, context.from, "", prefix, context)
 		} else {
 			names <- name
 		}
 	}
 }
 
-func listObjectsToCopy(names chan<- string, bucketname, continueFromKey, prefix string, context *CopyContext) {
+func listObjectsToCopy(ctx context.Context, names chan<- string, bucketname, continueFromKey, prefix string, context *CopyContext) {
 	// fmt.Printf("Batch size for list: %d\n", context.options.batchsize)
 	input := &s3.ListObjectsV2Input{
 		Bucket:  aws.String(bucketname),
-		MaxKeys: aws.Int64(context.options.batchsize),
+		MaxKeys: aws.Int32(int32(context.options.batchsize)),
 	}
 	if continueFromKey != "" {
 		input.StartAfter = &continueFromKey
@@ -131,120 +163,36 @@ func listObjectsToCopy(names chan<- string, bucketname, continueFromKey, prefix
 		input.Prefix = &prefix
 	}
 
-	err := context.s3svc.ListObjectsV2Pages(input,
-		func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-			// Could use following if cloudwatch based metrics are not available:
-			// atomic.AddInt64(&context.expectedObjects, int64(len(page.Contents)))
-			for _, item := range page.Contents {
-				names <- *item.Key
+	err := context.sourcesvc.ListObjectsV2Pages(ctx, input, func(page *s3.ListObjectsV2Output) bool {
+		if context.streamingSize {
+			atomic.AddInt64(&context.expectedObjects, int64(len(page.Contents)))
+		}
+		for _, item := range page.Contents {
+			if context.journal != nil && context.journal.IsDone(*item.Key) {
+				continue // already copied in a previous, interrupted run of this --job-id
+			}
+			select {
+			case names <- *item.Key:
+			case <-ctx.Done():
+				return false
 			}
-			// stop pumping names once we have copied enough
-			return context.copiedObjects < context.maxObjectsToCopy
-		})
+		}
+		// stop pumping names once we have copied enough
+		return context.copiedObjects < context.maxObjectsToCopy
+	})
 	if err != nil {
 		os.Stderr.WriteString(fmt.Sprintf("%s", err))
 	}
 }
 
-func main() {
-	app := cli.NewApp()
-	app.Usage = "Set Cache-Control header for all objects in a s3 bucket. Optionally copies objects from another bucket."
-	app.Version = "0.1"
-	app.Flags = []cli.Flag{
-		cli.StringFlag{
-			Name:  "target-bucket, t",
-			Usage: "where changes will happen: objects added or meta-data changed",
-		},
-		cli.StringFlag{
-			Name:  "from-bucket, f",
-			Usage: "if omitted, use in-place-copy (target-bucket=from-bucket)",
-		},
-		cli.StringFlag{
-			Name:  "cache-control, c",
-			Value: "max-age=31536000,public",
-			Usage: "by default cache for one year",
-		},
-		cli.IntFlag{
-			Name:  "parallelity, p",
-			Value: 200,
-			Usage: "number of workers to use",
-		},
-		cli.BoolFlag{
-			Name:  "noop",
-			Usage: "make no changes, just gather statistics",
-		},
-		cli.StringFlag{
-			Name:  "exclude-pictures, e",
-			Usage: "do not process picture object which names match regex",
-		},
-		cli.IntFlag{
-			Name:  "first-n, n",
-			Value: math.MaxInt64,
-			Usage: "stop copy/process roughly after first n entries; skipped\n\tand ignored do not count",
-		},
-		cli.StringFlag{
-			Name:  "continue, u",
-			Usage: "do not start over, continue from given key",
-		},
-		cli.BoolFlag{
-			Name:  "stdin",
-			Usage: "take file names to copy from stdin",
-		},
-		cli.StringFlag{
-			Name: "cross-account-cloudwatch-role, r",
-			Usage: `
-
-	Sometimes you need to copy objects between buckets from different accounts
-	(cross-account), e.g. prod- vs. nonprod- account. Obviously you need to give
-	the account you currently use write permission to the target bucket and read
-	permission to the 'from' bucket. But to also have a correct progress bar for
-	the long running copy operation, you need to give your account the permission
-	to access cloudwatch metrics for the 'from' bucket.
-
-			`,
-		},
-	}
-	app.Action = func(c *cli.Context) error {
-		context, _ := prepareContextFromCli(c)
-
-		// set well below the typical ulimit of 1024 - TODO add to docs
-		// to avoid "socket: too many open files".
-		// Also fits AWS API limits, avoid "503 SlowDown: Please reduce your request rate."
-		parallelity := c.GlobalInt("parallelity")
-
-		names := make(chan string, context.options.batchsize*3) // enable uninterrupted stream of files to copy
-		events := make(chan CopyResult, 10000)
-		getExpectedSize(&context)
-
-		context.wg.Add(parallelity)
-		for gr := 1; gr <= parallelity; gr++ {
-			go cpworker(&context, names, events)
-		}
-		waitStats := sync.WaitGroup{}
-		waitStats.Add(1)
-		go processStats(context.expectedObjects, events, &waitStats)
-
-		if c.GlobalBool("stdin") {
-			listObjectsFromStdin(names, &context)
-		} else {
-			listObjectsToCopy(names, context.from, c.GlobalString("continue"), "", &context)
-		}
-		close(names)
-		context.wg.Wait()
-		close(events)
-		waitStats.Wait()
-		fmt.Printf("\nDone.\n")
-		return nil
-	}
-	app.Run(os.Args)
-}
-
 func CheckPublicCommentTmp() {
 }
 
 /* CopyContext defines context for running concurrent copy operations and remembers the progress */
 type CopyContext struct {
-	s3svc *s3.S3
+	sourcesvc Backend
+	targetsvc Backend
+	cwsvc     *cloudwatch.Client
 
 	options CopyOptions
 
@@ -258,6 +206,16 @@ type CopyContext struct {
 	maxObjectsToCopy int64
 	expectedObjects  int64
 	copiedObjects    int64
+	streamingSize    bool // true once expectedObjects is only an incremental estimate, see getExpectedSize
+
+	multipartThreshold int64 // objects larger than this use multipartCopy instead of a single CopyObject
+	partSize           int64
+	partConcurrency    int
+
+	journal *Journal // non-nil when running under --job-id, see journal.go
+
+	limiter     *RateLimiter // shared --rps/--burst/AIMD throttle, see throttle.go
+	maxAttempts int          // retry attempts per HeadObject/CopyObject/UploadPartCopy before giving up
 
 	wg sync.WaitGroup
 }
@@ -274,87 +232,22 @@ type CopyOptions struct {
 
 type CopyResult struct {
 	status, key, contenttype string
+	size                     int64
+	sourceETag, targetETag   string
 	err                      error
 }
 
-func prepareContext() (CopyContext, error) {
-	// Session with the new library
-	sess, err := session.NewSession() /*&aws.Config{
-		Region: aws.String("eu-central-1")},
-	)*/
-	if err != nil {
-		panic(fmt.Sprintf("Can not create AWS SDK session %s", err))
-	}
-
-	if len(os.Args) != 3 {
-		panic("Please provide bucket name and desired Cache-Control setting")
-	}
-	return CopyContext{
-		s3svc:           s3.New(sess),
-		target:          os.Args[1],
-		expectedObjects: 3867874,
-		newvalue:        os.Args[2],
-	}, nil
-}
-
-func prepareContextFromCli(c *cli.Context) (CopyContext, error) {
-	// Session with the new library
-	sess, err := session.NewSession() /*&aws.Config{
-		Region: aws.String("eu-central-1")},
-	)*/
-	if err != nil {
-		panic(fmt.Sprintf("Can not create AWS SDK session %s", err))
-	}
-
-	target := c.GlobalString("target-bucket")
-	if target == "" {
-		cli.ShowAppHelp(c)
-		return CopyContext{}, cli.NewExitError("\n\nError: --target-bucket is a required flag\n", 1)
-	}
-
-	from := c.GlobalString("from-bucket")
-	if from == "" {
-		from = target
-	}
-
-	fmt.Printf("Copying   to %s\nCopying from %s\n", target, from)
-
-	exclude_pattern := c.GlobalString("exclude-pictures")
-	if exclude_pattern == "" {
-		exclude_pattern = "^some-pattern-which-would-never-match$"
-	}
-
-	o := CopyOptions{}
-	o.batchsize = c.GlobalInt64("first-n") / 2
-	if o.batchsize > 1000 {
-		o.batchsize = 1000
-	}
-	if o.batchsize < 10 {
-		o.batchsize = 10
-	}
-
-	return CopyContext{
-		s3svc:            s3.New(sess),
-		target:           target,
-		from:             from,
-		noop:             c.GlobalBool("noop"),
-		expectedObjects:  0,
-		maxObjectsToCopy: c.GlobalInt64("first-n"),
-		newvalue:         c.GlobalString("cache-control"),
-		exclude:          *regexp.MustCompile(exclude_pattern),
-		cloudwatchRole:   c.GlobalString("cross-account-cloudwatch-role"),
-		options:          o,
-	}, nil
-}
-
-func cpworker(context *CopyContext, names <-chan string, events chan<- CopyResult) {
+func cpworker(ctx context.Context, context *CopyContext, names <-chan string, events chan<- CopyResult) {
 	for {
-		name, more := <-names
-		if more {
+		select {
+		case name, more := <-names:
+			if !more {
+				context.wg.Done()
+				return
+			}
 			// fmt.Printf("Starting copy %v\n", name)
-			events <- cp(context, name)
-		} else {
-			// fmt.Printf("\nNo more data in names channel.\n")
+			events <- cp(ctx, context, name)
+		case <-ctx.Done():
 			context.wg.Done()
 			return
 		}
@@ -387,40 +280,62 @@ func str(o *s3.HeadObjectOutput) string {
 	}
 }
 
-func cp(context *CopyContext, name string) CopyResult {
+// errorCode extracts the AWS error code from err, if any.
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func cp(ctx context.Context, context *CopyContext, name string) CopyResult {
 	//fmt.Println(context.target)
 	//fmt.Println(url.PathEscape(name))
 	// key := aws.String(url.PathEscape(name)),
+	start := time.Now()
+	defer func() { copyDurationSeconds.Observe(time.Since(start).Seconds()) }()
+
 	key := name
 	res := CopyResult{status: "X", key: key}
-	from, fromErr := context.s3svc.HeadObject(&s3.HeadObjectInput{
-		Bucket: aws.String(context.from),
-		Key:    aws.String(key),
+	var from *s3.HeadObjectOutput
+	fromErr := withRetry(ctx, context.limiter, context.maxAttempts, func() error {
+		var err error
+		from, err = context.sourcesvc.HeadObject(ctx, context.from, key)
+		return err
 	})
+	headRequestsTotal.Inc()
 	if fromErr != nil {
 		res.err = fmt.Errorf("\naws sdk Head for `%s` failed: \n%T\n%v\n", key, fromErr, fromErr)
+		objectsFailedTotal.Inc()
 		return res
 	}
+	if from.ContentLength != nil {
+		res.size = *from.ContentLength
+	}
+	if from.ETag != nil {
+		res.sourceETag = *from.ETag
+	}
 
 	contenttype := from.ContentType
 
-	target, targetErr := context.s3svc.HeadObject(&s3.HeadObjectInput{
-		Bucket: aws.String(context.target),
-		Key:    aws.String(key),
+	var target *s3.HeadObjectOutput
+	targetErr := withRetry(ctx, context.limiter, context.maxAttempts, func() error {
+		var err error
+		target, err = context.targetsvc.HeadObject(ctx, context.target, key)
+		return err
 	})
+	headRequestsTotal.Inc()
+	if targetErr == nil && target.ETag != nil {
+		res.targetETag = *target.ETag
+	}
 	if targetErr != nil {
-		if aerr, ok := targetErr.(awserr.Error); ok {
-			switch aerr.Code() {
-			case "NotFound":
-				target = nil
-			default:
-				os.Stderr.WriteString(fmt.Sprintf("\n***Missing target Head for `%s` failed (code %s): \n%T\n%v\n",
-					key, aerr.Code(), targetErr, targetErr))
-			}
-		} else {
-			res.err = fmt.Errorf("\naws sdk Head for target `%s` failed, can not recognize the aws return code: \n%T\n%v\n",
-				key, fromErr, fromErr)
-			return res
+		switch errorCode(targetErr) {
+		case "NotFound", "NoSuchKey":
+			target = nil
+		default:
+			os.Stderr.WriteString(fmt.Sprintf("\n***Missing target Head for `%s` failed (code %s): \n%T\n%v\n",
+				key, errorCode(targetErr), targetErr, targetErr))
 		}
 	}
 
@@ -457,21 +372,34 @@ func cp(context *CopyContext, name string) CopyResult {
 			}
 		}
 
-		src := fmt.Sprintf("%s/%s", context.from, url.PathEscape(name))
-		inp := s3.CopyObjectInput{
-			Bucket:            aws.String(context.target),
-			CopySource:        &src,
-			Key:               &name,
-			CacheControl:      &context.newvalue,
-			ContentType:       contenttype,
-			MetadataDirective: aws.String("REPLACE"),
-		}
 		if !context.noop {
-			_, err := context.s3svc.CopyObject(&inp)
-			if err != nil {
-				res.err = fmt.Errorf("Failed changing (inplace-copying) object: %v", err)
-				return res
+			if res.size > context.multipartThreshold {
+				if err := multipartCopy(ctx, context, name, res.size, contenttype); err != nil {
+					res.err = err
+					objectsFailedTotal.Inc()
+					return res
+				}
+			} else {
+				src := fmt.Sprintf("%s/%s", context.from, url.PathEscape(name))
+				inp := s3.CopyObjectInput{
+					Bucket:            aws.String(context.target),
+					CopySource:        &src,
+					Key:               &name,
+					CacheControl:      &context.newvalue,
+					ContentType:       contenttype,
+					MetadataDirective: "REPLACE",
+				}
+				err := withRetry(ctx, context.limiter, context.maxAttempts, func() error {
+					_, err := context.targetsvc.CopyObject(ctx, &inp)
+					return err
+				})
+				if err != nil {
+					res.err = fmt.Errorf("Failed changing (inplace-copying) object: %v", err)
+					objectsFailedTotal.Inc()
+					return res
+				}
 			}
+			bytesCopiedTotal.Add(float64(res.size))
 		}
 		atomic.AddInt64(&context.copiedObjects, 1)
 	}
@@ -481,10 +409,19 @@ func cp(context *CopyContext, name string) CopyResult {
 	} else {
 		res.contenttype = *contenttype
 	}
+	objectsCopiedTotal.WithLabelValues(res.status).Inc()
 	return res
 }
 
-func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGroup) {
+// processStats consumes copy events, keeps running totals, writes the
+// per-object log files, and renders progress per --progress:
+//   - "tty":  the original dot-per-object stream plus a periodic summary table
+//   - "json": one JSON line per event on stdout plus the periodic summary table
+//   - "none": no progress or summary output; failures still go to stderr
+//
+// In every mode, each event is also logged as structured JSON via slog to
+// the run's -objects.jsonl file.
+func processStats(context *CopyContext, events <-chan CopyResult, running *sync.WaitGroup, progress string) {
 	var processedObjects int64 // including ignored and skipped
 	start := time.Now()
 	statusStats := make(map[string]int)
@@ -492,10 +429,14 @@ func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGr
 	last := ""
 	every := time.NewTicker(12 * time.Second)
 
+	expected := func() int64 { return atomic.LoadInt64(&context.expectedObjects) }
+
 	showStats := func() {
+		exp := expected()
+		expectedObjectsGauge.Set(float64(exp))
 		sec := time.Since(start).Seconds()
 		o_s := float64(processedObjects) / sec
-		expectedDuration := time.Duration(int(float64(expected-processedObjects)/o_s)) * time.Second
+		expectedDuration := time.Duration(int(float64(exp-processedObjects)/o_s)) * time.Second
 		days := int(expectedDuration.Hours() / 24)
 		andHours := expectedDuration.Hours() - float64(days)*24
 		eta := fmt.Sprintf("%dd %.1fh", days, andHours)
@@ -504,12 +445,15 @@ func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGr
 			andMinutes := expectedDuration.Minutes() - float64(hours)*60
 			eta = fmt.Sprintf("%dh %.1fm", hours, andMinutes)
 		}
-		if expected < processedObjects {
+		if exp < processedObjects {
 			eta = "-"
 		}
 
+		if progress == "none" {
+			return
+		}
 		fmt.Printf("\n%-30s Totals: %d/%d objects. Avg: %.2f obj/s. ETA: %v    \n",
-			last, processedObjects, expected, o_s, eta,
+			last, processedObjects, exp, o_s, eta,
 		)
 		fmt.Printf("\nContent-Type stats:\n")
 		for k, v := range typeStats {
@@ -536,6 +480,8 @@ func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGr
 	fErrors := fileToWrite(run + "-error-keys.log")
 	defer fErrors.Close()
 
+	jsonLog := slog.New(slog.NewJSONHandler(fileToWrite(run+"-objects.jsonl"), nil))
+
 	for {
 		select {
 		case <-every.C:
@@ -544,10 +490,26 @@ func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGr
 		case event, more := <-events:
 			if more {
 				fmt.Fprintf(fList, "%s\t%s\t%s\n", event.status, event.contenttype, event.key)
+				errMsg := ""
 				if event.err != nil {
+					errMsg = event.err.Error()
 					os.Stderr.WriteString(fmt.Sprintf("==> Failed processing '%s': %v\n", event.key, event.err))
 					fmt.Fprintln(fErrors, event.key)
 				}
+				jsonLog.Info("copy", "key", event.key, "status", event.status,
+					"sourceETag", event.sourceETag, "targetETag", event.targetETag,
+					"size", event.size, "error", errMsg)
+				if context.journal != nil {
+					journalStatus := event.status
+					if event.err != nil {
+						journalStatus = "R" // recoverable: retried on the next --job-id resume
+					}
+					context.journal.Record(event.key, journalStatus)
+				}
+				if progress == "json" {
+					fmt.Printf("{\"key\":%q,\"status\":%q,\"sourceETag\":%q,\"targetETag\":%q,\"size\":%d,\"error\":%q}\n",
+						event.key, event.status, event.sourceETag, event.targetETag, event.size, errMsg)
+				}
 
 				statusStats[event.status] += 1
 				// extract interesting part before semicolon, like "mulitpart/package"
@@ -556,9 +518,13 @@ func processStats(expected int64, events <-chan CopyResult, running *sync.WaitGr
 				typeStats[ctype] += 1
 				processedObjects += 1
 				last = event.key
-				fmt.Print(event.status)
+				if progress == "tty" {
+					fmt.Print(event.status)
+				}
 			} else {
-				fmt.Printf("\n\n## Event channel closed. Final statistics:\n")
+				if progress != "none" {
+					fmt.Printf("\n\n## Event channel closed. Final statistics:\n")
+				}
 				showStats()
 				running.Done()
 				return
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// RunOptions is everything executeCopy needs to run a job. It is built from
+// the CLI flags for a fresh run (the app's default action), and round-trips through
+// config.json (see journal.go) so `resume` and `verify` can rebuild the same
+// job without repeating the command line.
+type RunOptions struct {
+	TargetBucket string
+	FromBucket   string
+	CacheControl string
+	Parallelity  int
+	Noop         bool
+	ExcludeRegex string
+	FirstN       int64
+	Continue     string
+	Stdin        bool
+
+	CloudwatchRole  string
+	SourceRole      string
+	TargetRole      string
+	ExternalID      string
+	RoleSessionName string
+	Duration        time.Duration
+	MFASerial       string
+	Region          string
+
+	Endpoint       string
+	SourceEndpoint string
+	TargetEndpoint string
+	PathStyle      bool
+	DisableSSL     bool
+
+	MultipartThreshold int64
+	PartSize           int64
+	PartConcurrency    int
+
+	ListShards        int
+	ListShardAlphabet string
+	PrefixFile        string
+	ListPageSize      int64
+	ListPageSizeSet   bool
+
+	MetricsListen string
+	Progress      string
+
+	JobID  string
+	JobDir string
+
+	RPS        float64
+	Burst      int
+	MaxRetries int
+}
+
+func optionsFromCli(c *cli.Context) RunOptions {
+	excludePattern := c.GlobalString("exclude-pictures")
+	if excludePattern == "" {
+		excludePattern = "^some-pattern-which-would-never-match$"
+	}
+	return RunOptions{
+		TargetBucket:       c.GlobalString("target-bucket"),
+		FromBucket:         c.GlobalString("from-bucket"),
+		CacheControl:       c.GlobalString("cache-control"),
+		Parallelity:        c.GlobalInt("parallelity"),
+		Noop:               c.GlobalBool("noop"),
+		ExcludeRegex:       excludePattern,
+		FirstN:             c.GlobalInt64("first-n"),
+		Continue:           c.GlobalString("continue"),
+		Stdin:              c.GlobalBool("stdin"),
+		CloudwatchRole:     c.GlobalString("cross-account-cloudwatch-role"),
+		SourceRole:         c.GlobalString("source-role"),
+		TargetRole:         c.GlobalString("target-role"),
+		ExternalID:         c.GlobalString("external-id"),
+		RoleSessionName:    c.GlobalString("role-session-name"),
+		Duration:           c.GlobalDuration("duration"),
+		MFASerial:          c.GlobalString("mfa-serial"),
+		Region:             c.GlobalString("region"),
+		Endpoint:           c.GlobalString("endpoint"),
+		SourceEndpoint:     c.GlobalString("source-endpoint"),
+		TargetEndpoint:     c.GlobalString("target-endpoint"),
+		PathStyle:          c.GlobalBool("path-style"),
+		DisableSSL:         c.GlobalBool("disable-ssl"),
+		MultipartThreshold: c.GlobalInt64("multipart-threshold"),
+		PartSize:           c.GlobalInt64("part-size"),
+		PartConcurrency:    c.GlobalInt("part-concurrency"),
+		ListShards:         c.GlobalInt("list-shards"),
+		ListShardAlphabet:  c.GlobalString("list-shard-alphabet"),
+		PrefixFile:         c.GlobalString("prefix-file"),
+		ListPageSize:       c.GlobalInt64("list-page-size"),
+		ListPageSizeSet:    c.IsSet("list-page-size"),
+		MetricsListen:      c.GlobalString("metrics-listen"),
+		Progress:           c.GlobalString("progress"),
+		JobID:              c.GlobalString("job-id"),
+		JobDir:             c.GlobalString("job-dir"),
+		RPS:                c.GlobalFloat64("rps"),
+		Burst:              c.GlobalInt("burst"),
+		MaxRetries:         c.GlobalInt("max-retries"),
+	}
+}
+
+// displayEndpoint renders an endpoint for an error message, naming the
+// default AWS S3 service explicitly instead of showing an empty string.
+func displayEndpoint(endpoint string) string {
+	if endpoint == "" {
+		return "AWS S3 (default)"
+	}
+	return endpoint
+}
+
+// prepareContext builds a CopyContext (credentials, backends, options) from
+// RunOptions. It used to take a *cli.Context directly; going through
+// RunOptions instead means `resume` can call it with a config.json loaded
+// from disk instead of from the command line.
+func prepareContext(ctx context.Context, opts RunOptions) (CopyContext, error) {
+	target := opts.TargetBucket
+	if target == "" {
+		return CopyContext{}, cli.NewExitError("\n\nError: --target-bucket is a required flag\n", 1)
+	}
+
+	from := opts.FromBucket
+	if from == "" {
+		from = target
+	}
+
+	fmt.Printf("Copying   to %s\nCopying from %s\n", target, from)
+
+	o := CopyOptions{}
+	if opts.ListPageSizeSet {
+		o.batchsize = opts.ListPageSize
+	} else {
+		o.batchsize = opts.FirstN / 2
+		if o.batchsize > 1000 {
+			o.batchsize = 1000
+		}
+		if o.batchsize < 10 {
+			o.batchsize = 10
+		}
+	}
+
+	roleOpts := func(role string) RoleOptions {
+		return RoleOptions{
+			RoleArn: role, ExternalID: opts.ExternalID,
+			SessionName: opts.RoleSessionName, Duration: opts.Duration, MFASerial: opts.MFASerial,
+		}
+	}
+
+	sourceCfg, err := loadAWSConfig(ctx, opts.Region, roleOpts(opts.SourceRole))
+	if err != nil {
+		return CopyContext{}, fmt.Errorf("source bucket credentials: %v", err)
+	}
+	targetCfg, err := loadAWSConfig(ctx, opts.Region, roleOpts(opts.TargetRole))
+	if err != nil {
+		return CopyContext{}, fmt.Errorf("target bucket credentials: %v", err)
+	}
+	cwCfg, err := loadAWSConfig(ctx, opts.Region, roleOpts(opts.CloudwatchRole))
+	if err != nil {
+		return CopyContext{}, fmt.Errorf("cloudwatch credentials: %v", err)
+	}
+
+	sourceEndpoint := opts.SourceEndpoint
+	if sourceEndpoint == "" {
+		sourceEndpoint = opts.Endpoint
+	}
+	targetEndpoint := opts.TargetEndpoint
+	if targetEndpoint == "" {
+		targetEndpoint = opts.Endpoint
+	}
+	if sourceEndpoint != targetEndpoint {
+		return CopyContext{}, cli.NewExitError(fmt.Sprintf(
+			"\n\nError: --source-endpoint (%q) and --target-endpoint (%q) must be the same.\n"+
+				"CopyObject/UploadPartCopy resolve CopySource within the single service handling\n"+
+				"the request, not across hosts, so server-side copying between two different\n"+
+				"S3-compatible services (e.g. AWS to MinIO) is not supported yet.\n",
+			displayEndpoint(sourceEndpoint), displayEndpoint(targetEndpoint)), 1)
+	}
+
+	firstN := opts.FirstN
+	if firstN == 0 {
+		firstN = math.MaxInt64
+	}
+
+	maxAttempts := opts.MaxRetries
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxRetries
+	}
+
+	return CopyContext{
+		sourcesvc: newBackend(sourceCfg, BackendOptions{
+			Endpoint: sourceEndpoint, PathStyle: opts.PathStyle, DisableSSL: opts.DisableSSL,
+		}),
+		targetsvc: newBackend(targetCfg, BackendOptions{
+			Endpoint: targetEndpoint, PathStyle: opts.PathStyle, DisableSSL: opts.DisableSSL,
+		}),
+		cwsvc:              cloudwatch.NewFromConfig(cwCfg),
+		target:             target,
+		from:               from,
+		noop:               opts.Noop,
+		expectedObjects:    0,
+		maxObjectsToCopy:   firstN,
+		newvalue:           opts.CacheControl,
+		exclude:            *regexp.MustCompile(opts.ExcludeRegex),
+		cloudwatchRole:     opts.CloudwatchRole,
+		options:            o,
+		multipartThreshold: opts.MultipartThreshold,
+		partSize:           opts.PartSize,
+		partConcurrency:    opts.PartConcurrency,
+		limiter:            newRateLimiter(opts.RPS, opts.Burst, opts.Parallelity),
+		maxAttempts:        maxAttempts,
+	}, nil
+}
+
+// executeCopy runs one copy job end to end: prepare credentials/backends,
+// optionally attach a --job-id journal, fan out listing and workers, and
+// wait for everything to drain. Both the app's default action and `resume`
+// call this with a RunOptions built from different sources.
+func executeCopy(ctx context.Context, opts RunOptions) error {
+	copyContext, err := prepareContext(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.JobID != "" {
+		jobDir := opts.JobDir
+		if jobDir == "" {
+			jobDir = "./jobs"
+		}
+		journal, err := openJournal(jobDir, opts.JobID)
+		if err != nil {
+			return fmt.Errorf("opening --job-id '%s': %v", opts.JobID, err)
+		}
+		defer journal.Close()
+		if err := journal.SaveConfig(opts); err != nil {
+			return fmt.Errorf("saving job config: %v", err)
+		}
+		copyContext.journal = journal
+	}
+
+	serveMetrics(opts.MetricsListen)
+
+	// set well below the typical ulimit of 1024 - TODO add to docs
+	// to avoid "socket: too many open files".
+	// Also fits AWS API limits, avoid "503 SlowDown: Please reduce your request rate."
+	parallelity := opts.Parallelity
+
+	names := make(chan string, copyContext.options.batchsize*3) // enable uninterrupted stream of files to copy
+	events := make(chan CopyResult, 10000)
+	getExpectedSize(ctx, &copyContext)
+
+	copyContext.wg.Add(parallelity)
+	for gr := 1; gr <= parallelity; gr++ {
+		go cpworker(ctx, &copyContext, names, events)
+	}
+	waitStats := sync.WaitGroup{}
+	waitStats.Add(1)
+	go processStats(&copyContext, events, &waitStats, opts.Progress)
+
+	switch {
+	case opts.Stdin:
+		listObjectsFromStdin(ctx, names, &copyContext)
+	case opts.PrefixFile != "":
+		prefixes, err := readPrefixFile(opts.PrefixFile)
+		if err != nil {
+			return fmt.Errorf("reading --prefix-file '%s': %v", opts.PrefixFile, err)
+		}
+		listObjectsSharded(ctx, names, &copyContext, prefixes, parseContinuePoints(opts.Continue))
+	case opts.ListShards > 0:
+		prefixes := shardPrefixes(opts.ListShardAlphabet, opts.ListShards)
+		listObjectsSharded(ctx, names, &copyContext, prefixes, parseContinuePoints(opts.Continue))
+	default:
+		listObjectsToCopy(ctx, names, copyContext.from, opts.Continue, "", &copyContext)
+	}
+	close(names)
+	copyContext.wg.Wait()
+	close(events)
+	waitStats.Wait()
+	if ctx.Err() != nil {
+		fmt.Printf("\nInterrupted, drained running workers.\n")
+	} else {
+		fmt.Printf("\nDone.\n")
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// retryableErrorCodes are AWS error codes worth retrying with backoff -
+// throttling and transient server-side failures. Anything else
+// (AccessDenied, NoSuchKey, ...) is terminal, same as before this file
+// existed.
+var retryableErrorCodes = map[string]bool{
+	"SlowDown":             true,
+	"RequestTimeout":       true,
+	"InternalError":        true,
+	"ServiceUnavailable":   true,
+	"RequestTimeTooSkewed": true,
+}
+
+const (
+	retryBaseDelay    = 200 * time.Millisecond
+	retryMaxDelay     = 30 * time.Second
+	defaultMaxRetries = 8
+)
+
+// isRetryable reports whether err is a transient AWS error worth retrying,
+// as opposed to e.g. AccessDenied or NoSuchKey which should fail immediately.
+func isRetryable(err error) bool {
+	return err != nil && retryableErrorCodes[errorCode(err)]
+}
+
+// withRetry calls fn up to maxAttempts times, retrying on isRetryable errors
+// with full-jitter exponential backoff (base 200ms, capped at 30s). Every
+// attempt, including retries, acquires its own limiter slot via withLimiter,
+// so --rps/--burst bound the actual HTTP calls reaching the backend, not just
+// the logical operations started. Every retryable failure is reported to
+// limiter, which drives the AIMD concurrency backoff in RateLimiter.throttled.
+func withRetry(ctx context.Context, limiter *RateLimiter, maxAttempts int, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = withLimiter(ctx, limiter, fn)
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) {
+			return err
+		}
+		if limiter != nil {
+			limiter.throttled()
+		}
+		if attempt == maxAttempts-1 {
+			return err
+		}
+		delay := retryBaseDelay * time.Duration(1<<uint(attempt))
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		delay = time.Duration(rand.Int63n(int64(delay) + 1)) // full jitter
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// aimdRecoverInterval is how often the concurrency budget is allowed to grow
+// by one worker again after a throttling episode (the additive-increase half
+// of AIMD).
+const aimdRecoverInterval = 10 * time.Second
+
+// RateLimiter is a token-bucket request limiter (--rps, --burst) shared by
+// all copy workers, combined with AIMD concurrency control: sustained
+// throttling (SlowDown etc, see throttled) halves the effective worker
+// count, which then recovers by one every aimdRecoverInterval as long as no
+// further throttling is observed. This lets --parallelity be set generously
+// and self-tune down instead of requiring a manually-picked, conservative
+// value.
+type RateLimiter struct {
+	tokens chan struct{}
+
+	mu           sync.Mutex
+	ceiling      int // current concurrency budget, halved by throttled()
+	maxCeiling   int // --parallelity, the ceiling's recovery target
+	inFlight     int
+	lastThrottle time.Time
+	lastGrow     time.Time
+}
+
+// newRateLimiter builds a RateLimiter allowing up to burst requests
+// immediately and rps requests per second thereafter, gating at most
+// maxConcurrency concurrent workers. rps<=0 disables the token bucket
+// (unlimited request rate); maxConcurrency<=0 disables AIMD throttling.
+func newRateLimiter(rps float64, burst, maxConcurrency int) *RateLimiter {
+	rl := &RateLimiter{ceiling: maxConcurrency, maxCeiling: maxConcurrency}
+	if rps <= 0 {
+		return rl
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	rl.tokens = make(chan struct{}, burst)
+	for i := 0; i < burst; i++ {
+		rl.tokens <- struct{}{}
+	}
+	go func() {
+		interval := time.Duration(float64(time.Second) / rps)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			select {
+			case rl.tokens <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return rl
+}
+
+// acquire blocks until a request token is available (--rps/--burst) and the
+// current AIMD concurrency budget admits another in-flight worker.
+func (rl *RateLimiter) acquire(ctx context.Context) error {
+	if rl.tokens != nil {
+		select {
+		case <-rl.tokens:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if rl.maxCeiling <= 0 {
+		return nil
+	}
+	for {
+		rl.mu.Lock()
+		rl.maybeGrowLocked()
+		if rl.inFlight < rl.ceiling {
+			rl.inFlight++
+			rl.mu.Unlock()
+			return nil
+		}
+		rl.mu.Unlock()
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release gives back the concurrency slot acquired by acquire.
+func (rl *RateLimiter) release() {
+	if rl.maxCeiling <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.inFlight--
+}
+
+// maybeGrowLocked is the additive-increase half of AIMD: once every
+// aimdRecoverInterval without a fresh throttling episode, grow the ceiling by
+// one worker, back up towards maxCeiling.
+func (rl *RateLimiter) maybeGrowLocked() {
+	if rl.ceiling >= rl.maxCeiling {
+		return
+	}
+	if time.Since(rl.lastThrottle) < aimdRecoverInterval || time.Since(rl.lastGrow) < aimdRecoverInterval {
+		return
+	}
+	rl.ceiling++
+	rl.lastGrow = time.Now()
+}
+
+// throttled is the multiplicative-decrease half of AIMD: called whenever a
+// retryable (throttling) error is observed, it immediately halves the
+// concurrency ceiling, down to a floor of 1.
+func (rl *RateLimiter) throttled() {
+	if rl.maxCeiling <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.lastThrottle = time.Now()
+	rl.ceiling = rl.ceiling / 2
+	if rl.ceiling < 1 {
+		rl.ceiling = 1
+	}
+}
+
+// withLimiter runs fn after acquiring a RateLimiter slot, always releasing it
+// afterwards. limiter may be nil, in which case fn runs unthrottled.
+func withLimiter(ctx context.Context, limiter *RateLimiter, fn func() error) error {
+	if limiter == nil {
+		return fn()
+	}
+	if err := limiter.acquire(ctx); err != nil {
+		return err
+	}
+	defer limiter.release()
+	return fn()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterThrottledHalvesCeiling(t *testing.T) {
+	rl := newRateLimiter(0, 0, 8)
+
+	rl.throttled()
+	if rl.ceiling != 4 {
+		t.Fatalf("ceiling after one throttled() = %d, want 4", rl.ceiling)
+	}
+
+	rl.throttled()
+	if rl.ceiling != 2 {
+		t.Fatalf("ceiling after two throttled() = %d, want 2", rl.ceiling)
+	}
+}
+
+func TestRateLimiterThrottledFloorsAtOne(t *testing.T) {
+	rl := newRateLimiter(0, 0, 2)
+
+	rl.throttled()
+	rl.throttled()
+	rl.throttled()
+	if rl.ceiling != 1 {
+		t.Fatalf("ceiling = %d, want floor of 1", rl.ceiling)
+	}
+}
+
+func TestRateLimiterMaybeGrowRecoversTowardsCeiling(t *testing.T) {
+	rl := newRateLimiter(0, 0, 4)
+	rl.ceiling = 1
+
+	// Simulate a throttling episode long enough ago that recovery is due.
+	rl.lastThrottle = time.Now().Add(-2 * aimdRecoverInterval)
+	rl.lastGrow = time.Now().Add(-2 * aimdRecoverInterval)
+
+	rl.mu.Lock()
+	rl.maybeGrowLocked()
+	rl.mu.Unlock()
+
+	if rl.ceiling != 2 {
+		t.Fatalf("ceiling after maybeGrowLocked = %d, want 2", rl.ceiling)
+	}
+}
+
+func TestRateLimiterMaybeGrowDoesNotExceedMaxCeiling(t *testing.T) {
+	rl := newRateLimiter(0, 0, 2)
+	rl.ceiling = 2
+	rl.lastThrottle = time.Now().Add(-2 * aimdRecoverInterval)
+	rl.lastGrow = time.Now().Add(-2 * aimdRecoverInterval)
+
+	rl.mu.Lock()
+	rl.maybeGrowLocked()
+	rl.mu.Unlock()
+
+	if rl.ceiling != 2 {
+		t.Fatalf("ceiling = %d, want unchanged at maxCeiling 2", rl.ceiling)
+	}
+}
+
+func TestRateLimiterMaybeGrowWaitsOutRecoverInterval(t *testing.T) {
+	rl := newRateLimiter(0, 0, 4)
+	rl.ceiling = 1
+	rl.lastThrottle = time.Now() // just throttled, recovery not due yet
+	rl.lastGrow = time.Now().Add(-2 * aimdRecoverInterval)
+
+	rl.mu.Lock()
+	rl.maybeGrowLocked()
+	rl.mu.Unlock()
+
+	if rl.ceiling != 1 {
+		t.Fatalf("ceiling = %d, want unchanged right after a throttle", rl.ceiling)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// verifyJob re-Heads every key a --job-id run recorded as done and reports
+// any that don't actually carry the Cache-Control the job was set up to
+// apply, or that are missing a Content-Type entirely. It only touches the
+// target bucket, so it works from just the journal's config.json - no
+// source credentials needed.
+func verifyJob(ctx context.Context, opts RunOptions) error {
+	jobDir := opts.JobDir
+	if jobDir == "" {
+		jobDir = "./jobs"
+	}
+	journal, err := openJournal(jobDir, opts.JobID)
+	if err != nil {
+		return fmt.Errorf("opening --job-id '%s': %v", opts.JobID, err)
+	}
+	defer journal.Close()
+
+	targetCfg, err := loadAWSConfig(ctx, opts.Region, RoleOptions{
+		RoleArn: opts.TargetRole, ExternalID: opts.ExternalID,
+		SessionName: opts.RoleSessionName, Duration: opts.Duration, MFASerial: opts.MFASerial,
+	})
+	if err != nil {
+		return fmt.Errorf("target bucket credentials: %v", err)
+	}
+	targetEndpoint := opts.TargetEndpoint
+	if targetEndpoint == "" {
+		targetEndpoint = opts.Endpoint
+	}
+	targetsvc := newBackend(targetCfg, BackendOptions{
+		Endpoint: targetEndpoint, PathStyle: opts.PathStyle, DisableSSL: opts.DisableSSL,
+	})
+
+	keys := journal.DoneKeys()
+	fmt.Printf("Verifying %d keys recorded as done for job '%s'...\n", len(keys), opts.JobID)
+
+	drifted := 0
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		meta, err := targetsvc.HeadObject(ctx, opts.TargetBucket, key)
+		if err != nil {
+			drifted++
+			fmt.Printf("MISSING  %s: %v\n", key, err)
+			continue
+		}
+		if meta.CacheControl == nil || *meta.CacheControl != opts.CacheControl {
+			drifted++
+			fmt.Printf("DRIFTED  %s: Cache-Control=%s\n", key, str(meta))
+			continue
+		}
+		// ContentType has no single expected value to compare against (the
+		// tool preserves whatever the source object carried), but it must
+		// never be nil - that's the property cp() guarantees by defaulting
+		// to image/png, see cp() in put-cache-control.go.
+		if meta.ContentType == nil {
+			drifted++
+			fmt.Printf("DRIFTED  %s: Content-Type=%s\n", key, str(meta))
+		}
+	}
+
+	fmt.Printf("\nDone. %d/%d keys missing or drifted from the expected Cache-Control/Content-Type.\n", drifted, len(keys))
+	return nil
+}